@@ -0,0 +1,126 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"bramp.net/antlr4-grammars/internal"
+)
+
+// buildCache persists the build-key that produced a grammar's generated Go
+// files, so unchanged grammars can be skipped on the next run. It is safe
+// for concurrent use by the worker pool.
+type buildCache struct {
+	path string
+
+	mu   sync.Mutex
+	Keys map[string]string `json:"keys"` // grammar name -> build-key
+}
+
+func openBuildCache(path string) (*buildCache, error) {
+	c := &buildCache{path: path, Keys: make(map[string]string)}
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if err := json.NewDecoder(f).Decode(&c.Keys); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	return c, nil
+}
+
+// Save writes the cache back to disk. It is not safe to call concurrently
+// with Get/Put.
+func (c *buildCache) Save() error {
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return err
+	}
+
+	tmp := c.path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(c.Keys); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, c.path)
+}
+
+// Get returns the previously recorded build-key for name, and whether one
+// was found.
+func (c *buildCache) Get(name string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key, ok := c.Keys[name]
+	return key, ok
+}
+
+// Put records key as the build-key that produced name's generated files.
+func (c *buildCache) Put(name, key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Keys[name] = key
+}
+
+// buildKey hashes everything that can change a grammar's generated output:
+// the mtime and size of each .g4 file and the project's resolved pom.xml,
+// options and imports. Two runs produce the same key iff none of those
+// inputs changed, which is all the incremental skip needs -- it doesn't
+// need to read file contents, just notice that they could have changed.
+func buildKey(p *internal.Project) (string, error) {
+	h := sha256.New()
+
+	fmt.Fprintf(h, "package=%s\n", p.PackageName())
+	fmt.Fprintf(h, "options=%v\n", p.Options)
+	fmt.Fprintf(h, "imports=%v\n", p.Imports)
+
+	var files []string
+	files = append(files, p.Includes...)
+	files = append(files, p.PomPath)
+
+	for _, f := range files {
+		fi, err := os.Stat(f)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "%s\t%d\t%s\n", f, fi.Size(), fi.ModTime())
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
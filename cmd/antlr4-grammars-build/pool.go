@@ -0,0 +1,159 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+
+	"bramp.net/antlr4-grammars/internal"
+)
+
+// buildResult is one row of the final status table.
+type buildResult struct {
+	Name    string
+	Skipped bool
+	Err     error
+	Detail  string // e.g. the last line of the failing step's log
+}
+
+// buildAll runs the antlr+build+test pipeline for every named project,
+// using a pool of jobs workers, and returns one buildResult per project in
+// the same order as names.
+func buildAll(names []string, projects map[string]*internal.Project, jar string, cache *buildCache, jobs int, force bool) []buildResult {
+	results := make([]buildResult, len(names))
+
+	sem := make(chan struct{}, jobs)
+	var wg sync.WaitGroup
+
+	for i, name := range names {
+		i, name := i, name
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = buildOne(name, projects[name], jar, cache, force)
+		}()
+	}
+
+	wg.Wait()
+	return results
+}
+
+// buildOne runs antlr, then regenerates the two files antlr doesn't
+// produce (register.go, which plugs the package into the grammars
+// registry, and the golden-corpus name_test.go), then builds and tests
+// the result.
+func buildOne(name string, p *internal.Project, jar string, cache *buildCache, force bool) buildResult {
+	logPath := filepath.Join(name, name+".log")
+	if err := os.MkdirAll(name, 0755); err != nil {
+		return buildResult{Name: name, Err: err, Detail: err.Error()}
+	}
+
+	logFile, err := os.Create(logPath)
+	if err != nil {
+		return buildResult{Name: name, Err: err, Detail: err.Error()}
+	}
+	defer logFile.Close()
+
+	key, err := buildKey(p)
+	if err != nil {
+		return buildResult{Name: name, Err: err, Detail: err.Error()}
+	}
+
+	if !force {
+		// A matching key only says the inputs are unchanged; the generated
+		// files must also still be there (cleanGenerated removes them).
+		if prev, ok := cache.Get(name); ok && prev == key {
+			if _, err := os.Stat(filepath.Join(name, "register.go")); err == nil {
+				return buildResult{Name: name, Skipped: true}
+			}
+		}
+	}
+
+	steps := []struct {
+		label string
+		run   func() error
+	}{
+		{"antlr", func() error { return runANTLR(jar, name, p, logFile) }},
+		{"register", func() error { return runCmd(logFile, "go", "run", ".", "-only", name) }},
+		{"maketest", func() error { return runCmd(logFile, "go", "run", "./internal/tools/maketest", name) }},
+		{"build", func() error { return runCmd(logFile, "go", "build", "./"+name) }},
+		{"test", func() error { return runCmd(logFile, "go", "test", "-timeout", "10s", "./"+name) }},
+	}
+
+	for _, step := range steps {
+		if err := step.run(); err != nil {
+			return buildResult{Name: name, Err: fmt.Errorf("%s: %w", step.label, err), Detail: lastLine(logPath)}
+		}
+	}
+
+	cache.Put(name, key)
+
+	// Drop the log if no step wrote anything to it.
+	if fi, err := os.Stat(logPath); err == nil && fi.Size() == 0 {
+		os.Remove(logPath)
+	}
+
+	return buildResult{Name: name}
+}
+
+func runANTLR(jar, name string, p *internal.Project, log *os.File) error {
+	args := append([]string{"-jar", jar, "-Dlanguage=Go", "-visitor", "-package", name, "-o", name}, p.Includes...)
+	return runCmd(log, "java", args...)
+}
+
+func runCmd(log *os.File, name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	cmd.Stdout = log
+	cmd.Stderr = log
+	return cmd.Run()
+}
+
+// lastLine returns the last non-empty line of the file at path, for
+// inclusion in the status table when a step fails.
+func lastLine(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+
+	lines := splitNonEmptyLines(string(data))
+	if len(lines) == 0 {
+		return ""
+	}
+	return lines[len(lines)-1]
+}
+
+func splitNonEmptyLines(s string) []string {
+	var out []string
+	start := 0
+	for i, r := range s {
+		if r == '\n' {
+			if line := s[start:i]; line != "" {
+				out = append(out, line)
+			}
+			start = i + 1
+		}
+	}
+	if line := s[start:]; line != "" {
+		out = append(out, line)
+	}
+	return out
+}
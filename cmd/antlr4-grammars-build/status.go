@@ -0,0 +1,38 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import "fmt"
+
+const (
+	langColor = "\033[0;36m"
+	noColor   = "\033[m"
+)
+
+// printStatusTable prints the same "| emoji | name | detail |" table the
+// old Makefile printed per-target, but as a single summary once every
+// grammar has finished building.
+func printStatusTable(results []buildResult) {
+	for _, r := range results {
+		switch {
+		case r.Err != nil:
+			fmt.Printf("| %s  | %s%-15s%s | %-75s |\n", "❌", langColor, r.Name, noColor, r.Detail)
+		case r.Skipped:
+			fmt.Printf("| %s  | %s%-15s%s | %-75s |\n", "➖", langColor, r.Name, noColor, "unchanged, skipped")
+		default:
+			fmt.Printf("| %s  | %s%-15s%s | %-75s |\n", "✅", langColor, r.Name, noColor, "")
+		}
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package grammars is a registry of the generated grammars in this
+// module. Each generated package registers itself in an init() function,
+// so once a grammar's package is imported (a blank import is enough),
+// callers can look it up by name and drive it without using its concrete
+// lexer and parser types. Lookup returns nil for a grammar whose package
+// was not imported:
+//
+//	import _ "bramp.net/antlr4-grammars/memcached_protocol"
+//
+//	g := grammars.Lookup("memcached_protocol")
+//	tree, errs := grammars.ParseFile(g, "examples/example1.txt")
+package grammars
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/antlr/antlr4/runtime/Go/antlr"
+)
+
+// Grammar is the uniform interface every generated grammar package
+// implements, so it can be driven without knowing its concrete lexer and
+// parser types.
+type Grammar interface {
+	// Name is the grammar's package name, e.g. "memcached_protocol".
+	Name() string
+
+	// NewLexer constructs the grammar's lexer over input.
+	NewLexer(input antlr.CharStream) antlr.Lexer
+
+	// NewParser constructs the grammar's parser over stream.
+	NewParser(stream antlr.TokenStream) antlr.Parser
+
+	// EntryPoint calls the grammar's root rule on p and returns the
+	// resulting parse tree.
+	EntryPoint(p antlr.Parser) antlr.ParseTree
+
+	// Extensions lists the file extensions (without the leading dot)
+	// this grammar's examples typically use, e.g. []string{"txt"}.
+	Extensions() []string
+
+	// RuleNames returns the parser's rule names, indexed by rule index,
+	// so a parse tree can be rendered with antlr.TreesStringTree without
+	// the caller having to keep the antlr.Parser instance around.
+	RuleNames() []string
+}
+
+var (
+	mu       sync.RWMutex
+	registry = make(map[string]Grammar)
+)
+
+// Register adds g to the registry, keyed by g.Name(). It is meant to be
+// called from a generated package's init() function, and panics on a
+// duplicate name since that indicates two packages were generated for
+// the same grammar.
+func Register(g Grammar) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	name := g.Name()
+	if _, found := registry[name]; found {
+		panic(fmt.Sprintf("grammars: Register called twice for %q", name))
+	}
+	registry[name] = g
+}
+
+// Lookup returns the registered Grammar with the given name, or nil if
+// none was registered under that name.
+func Lookup(name string) Grammar {
+	mu.RLock()
+	defer mu.RUnlock()
+	return registry[name]
+}
+
+// Names returns the names of every registered grammar, sorted.
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseFile reads filename with a FileStream and parses it with g,
+// returning the resulting parse tree and any syntax errors collected
+// while doing so.
+func ParseFile(g Grammar, filename string) (antlr.ParseTree, []error) {
+	input, err := antlr.NewFileStream(filename)
+	if err != nil {
+		return nil, []error{err}
+	}
+	return Parse(g, input)
+}
+
+// Parse lexes and parses input with g, returning the resulting parse
+// tree and any syntax errors collected while doing so.
+func Parse(g Grammar, input antlr.CharStream) (antlr.ParseTree, []error) {
+	lexer := g.NewLexer(input)
+
+	errs := &errorCollector{}
+	lexer.RemoveErrorListeners()
+	lexer.AddErrorListener(errs)
+
+	stream := antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel)
+	parser := g.NewParser(stream)
+	parser.RemoveErrorListeners()
+	parser.AddErrorListener(errs)
+	parser.SetBuildParseTrees(true)
+
+	tree := g.EntryPoint(parser)
+	return tree, errs.errors
+}
+
+// errorCollector is an antlr.ErrorListener that records every syntax
+// error instead of printing it to stderr.
+type errorCollector struct {
+	*antlr.DefaultErrorListener
+	errors []error
+}
+
+func (e *errorCollector) SyntaxError(recognizer antlr.Recognizer, offendingSymbol interface{}, line, column int, msg string, ex antlr.RecognitionException) {
+	e.errors = append(e.errors, fmt.Errorf("line %d:%d %s", line, column, msg))
+}
@@ -0,0 +1,48 @@
+// Package grammars_test contains a table-driven test that exercises every
+// registered grammar, replacing the near-identical boilerplate that used
+// to be repeated in each generated package's *_test.go.
+package grammars_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"bramp.net/antlr4-grammars/grammars"
+
+	// Importing a grammar package for its side effect registers it.
+	_ "bramp.net/antlr4-grammars/memcached_protocol"
+)
+
+// TestParseExamples is a coarse smoke test: every registered grammar
+// should parse its own examples without error (or, for .bad.txt
+// examples, should produce at least one). It doesn't check the parse
+// tree itself -- that's what the golden .tree files generated by
+// internal/tools/maketest are for.
+func TestParseExamples(t *testing.T) {
+	for _, name := range grammars.Names() {
+		name := name
+		t.Run(name, func(t *testing.T) {
+			g := grammars.Lookup(name)
+			if g == nil {
+				t.Fatalf("grammars.Lookup(%q) returned nil after grammars.Names() listed it", name)
+			}
+
+			examples, err := filepath.Glob(filepath.Join("..", "grammars-v4", name, "examples", "*.txt"))
+			if err != nil {
+				t.Fatalf("failed to glob examples: %s", err)
+			}
+
+			for _, example := range examples {
+				_, errs := grammars.ParseFile(g, example)
+				isBad := strings.HasSuffix(example, ".bad.txt")
+
+				if isBad && len(errs) == 0 {
+					t.Errorf("%s is a .bad.txt example but produced no syntax errors", example)
+				} else if !isBad && len(errs) > 0 {
+					t.Errorf("%s: unexpected syntax errors: %v", example, errs)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,218 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// makebom walks GRAMMARS_ROOT and produces bill-of-materials.json and
+// LICENSES.md, recording the upstream license and authorship of every
+// grammar this module vendors via the grammars-v4 submodule.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"bramp.net/antlr4-grammars/internal"
+)
+
+const GRAMMARS_ROOT = "grammars-v4"
+
+// highConfidence is the longest-common-substring ratio at or above which a
+// license match is trusted instead of reported as "unknown".
+const highConfidence = 0.9
+
+// license is a small library of known SPDX license texts to match
+// upstream LICENSE/COPYING files against. Texts are trimmed of
+// boilerplate headers (name, copyright line) since those vary per
+// project but the body doesn't.
+type license struct {
+	SPDX string
+	Text string
+}
+
+// licenseLibrary is intentionally small: it covers the licenses actually
+// seen across grammars-v4, not every SPDX identifier in existence.
+var licenseLibrary = []license{
+	{SPDX: "MIT", Text: mitLicenseText},
+	{SPDX: "Apache-2.0", Text: apache2LicenseText},
+	{SPDX: "BSD-3-Clause", Text: bsd3LicenseText},
+}
+
+// bomEntry is one row of bill-of-materials.json.
+type bomEntry struct {
+	Package      string   `json:"package"`
+	UpstreamPath string   `json:"upstreamPath"`
+	License      bomLic   `json:"license"`
+	Authors      []string `json:"authors,omitempty"`
+	URL          string   `json:"url,omitempty"`
+}
+
+type bomLic struct {
+	SPDX       string  `json:"spdx"`
+	Confidence float64 `json:"confidence"`
+}
+
+func main() {
+	var entries []bomEntry
+
+	err := filepath.Walk(GRAMMARS_ROOT, func(path string, info os.FileInfo, err error) error {
+		if err != nil || filepath.Base(path) != "pom.xml" {
+			return err
+		}
+
+		p, err := internal.ParsePom(path)
+		if err != nil {
+			return err
+		}
+
+		if !p.FoundAntlr4MavenPlugin || len(p.Includes) == 0 {
+			return nil
+		}
+
+		dir := filepath.Dir(path)
+		lic, confidence := classifyLicense(findLicenseFile(dir))
+
+		entries = append(entries, bomEntry{
+			Package:      p.PackageName(),
+			UpstreamPath: dir,
+			License:      bomLic{SPDX: lic, Confidence: confidence},
+			Authors:      p.Developers,
+			URL:          p.SCMURL,
+		})
+
+		return nil
+	})
+	if err != nil {
+		log.Fatalf("failed to walk %s: %s", GRAMMARS_ROOT, err)
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Package < entries[j].Package })
+
+	if err := writeJSON("bill-of-materials.json", entries); err != nil {
+		log.Fatalf("failed to write bill-of-materials.json: %s", err)
+	}
+
+	if err := writeMarkdown("LICENSES.md", entries); err != nil {
+		log.Fatalf("failed to write LICENSES.md: %s", err)
+	}
+}
+
+// findLicenseFile walks up from dir looking for a LICENSE or COPYING
+// file, stopping at GRAMMARS_ROOT. Most grammars don't carry their own
+// license and instead rely on the one at the root of grammars-v4.
+func findLicenseFile(dir string) string {
+	for {
+		for _, name := range []string{"LICENSE", "LICENSE.txt", "LICENSE.md", "COPYING"} {
+			candidate := filepath.Join(dir, name)
+			if _, err := os.Stat(candidate); err == nil {
+				return candidate
+			}
+		}
+
+		if dir == GRAMMARS_ROOT || dir == "." || dir == "/" {
+			return ""
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+func classifyLicense(path string) (spdx string, confidence float64) {
+	if path == "" {
+		return "unknown", 0
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "unknown", 0
+	}
+	text := string(data)
+
+	var bestSPDX string
+	var bestRatio float64
+	for _, lic := range licenseLibrary {
+		ratio := lcsSubstringRatio(text, lic.Text)
+		if ratio > bestRatio {
+			bestRatio = ratio
+			bestSPDX = lic.SPDX
+		}
+	}
+
+	if bestRatio >= highConfidence {
+		return bestSPDX, bestRatio
+	}
+	return "unknown", bestRatio
+}
+
+// lcsSubstringRatio returns len(longest common *contiguous* substring of
+// a and b) / len(b) -- how much of the reference license text b appears
+// verbatim, unbroken, somewhere in the candidate text a. Unlike a
+// subsequence match, interleaving b's characters with unrelated filler
+// does not score highly here.
+func lcsSubstringRatio(a, b string) float64 {
+	if len(b) == 0 {
+		return 0
+	}
+
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	var best int
+
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			if a[i-1] == b[j-1] {
+				curr[j] = prev[j-1] + 1
+				if curr[j] > best {
+					best = curr[j]
+				}
+			} else {
+				curr[j] = 0
+			}
+		}
+		prev, curr = curr, prev
+	}
+
+	return float64(best) / float64(len(b))
+}
+
+func writeJSON(path string, entries []bomEntry) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	return enc.Encode(entries)
+}
+
+func writeMarkdown(path string, entries []bomEntry) error {
+	var b strings.Builder
+
+	b.WriteString("# Licenses\n\n")
+	b.WriteString("Do not edit this file, it is generated by internal/tools/makebom\n\n")
+	b.WriteString("| Package | License | Confidence | Authors | Upstream |\n")
+	b.WriteString("|---|---|---|---|---|\n")
+
+	for _, e := range entries {
+		fmt.Fprintf(&b, "| %s | %s | %.2f | %s | %s |\n",
+			e.Package, e.License.SPDX, e.License.Confidence,
+			strings.Join(e.Authors, ", "), e.UpstreamPath)
+	}
+
+	return os.WriteFile(path, []byte(b.String()), 0644)
+}
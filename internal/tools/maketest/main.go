@@ -0,0 +1,277 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// maketest generates name_test.go for a single grammar package: a
+// table-driven test that parses every examples/*.txt file, diffs the
+// resulting parse tree against a checked-in golden file, and fails on
+// any unexpected syntax error.
+//
+// Usage:
+//
+//	go run ./internal/tools/maketest <package-dir>
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// defaultMaxTokens bounds how many tokens the lexer test will read before
+// giving up, in case a broken grammar causes it to never reach EOF. It
+// can be overridden per-grammar by testdata/config.yaml.
+const defaultMaxTokens = 1000000
+
+// defaultTimeout is the default per-example parse timeout written into the
+// generated test (parseopt.WithTimeout), also overridable by
+// testdata/config.yaml. It does not change the -timeout that
+// cmd/antlr4-grammars-build passes to `go test`, which is fixed at 10s.
+const defaultTimeout = "10s"
+
+// testConfig is testdata/config.yaml for a single grammar. Any field left
+// at its zero value falls back to the default.
+type testConfig struct {
+	MaxTokens int    `yaml:"max_tokens"`
+	Timeout   string `yaml:"timeout"`
+}
+
+// TEST is the template used to generate name_test.go. It drives the
+// grammar through parseopt rather than wiring up the raw antlr types, so
+// each generated test is a few lines instead of a page.
+const TEST = `// Package {{ .Name }}_test contains tests for the {{ .Name }} grammar.
+// The tests should be run with the -timeout flag, to ensure the parser
+// doesn't get stuck.
+//
+// Do not edit this file, it is generated by internal/tools/maketest
+//
+package {{ .Name }}_test
+
+import (
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"bramp.net/antlr4-grammars/grammars"
+	_ "bramp.net/antlr4-grammars/{{ .Name }}"
+	"bramp.net/antlr4-grammars/parseopt"
+	"github.com/antlr/antlr4/runtime/Go/antlr"
+)
+
+var update = flag.Bool("update", false, "update the golden .tree/.errors files instead of checking them")
+
+var examples = []string{
+{{- range .Examples }}
+	"{{ . }}",
+{{- end }}
+}
+
+func goldenPath(example, ext string) string {
+	return filepath.Join("..", example+ext)
+}
+
+func checkOrUpdate(t *testing.T, path, got string) {
+	t.Helper()
+
+	if *update {
+		if err := ioutil.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatalf("failed to update golden file %s: %s", path, err)
+		}
+		return
+	}
+
+	want, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file %s (run with -update to create it): %s", path, err)
+	}
+
+	if got != string(want) {
+		t.Errorf("%s mismatch (run with -update to refresh):\n got: %s\nwant: %s", path, got, want)
+	}
+}
+
+func Test{{ .Name }}(t *testing.T) {
+	g := grammars.Lookup("{{ .Name }}")
+
+	for _, example := range examples {
+		example := example
+		t.Run(example, func(t *testing.T) {
+			input, err := antlr.NewFileStream(filepath.Join("..", example))
+			if err != nil {
+				t.Fatalf("failed to open example file: %s", err)
+			}
+
+			var errs []error
+			tree, err := parseopt.Parse(g, input,
+				parseopt.WithErrorCollector(&errs),
+				parseopt.WithTimeout(mustParseDuration("{{ .Timeout }}")),
+				parseopt.WithMaxTokens({{ .MaxTokens }}))
+			if err != nil {
+				t.Fatalf("%s: %s", example, err)
+			}
+
+			isBad := strings.HasSuffix(example, ".bad.txt")
+
+			if isBad {
+				checkOrUpdate(t, goldenPath(example, ".errors"), joinErrors(errs)+"\n")
+				if len(errs) == 0 {
+					t.Errorf("%s is a .bad.txt example but produced no syntax errors", example)
+				}
+			} else {
+				if len(errs) > 0 {
+					t.Errorf("unexpected syntax errors: %v", errs)
+				}
+				checkOrUpdate(t, goldenPath(example, ".tree"), antlr.TreesStringTree(tree, g.RuleNames(), nil)+"\n")
+			}
+		})
+	}
+}
+
+func joinErrors(errs []error) string {
+	lines := make([]string, len(errs))
+	for i, err := range errs {
+		lines[i] = err.Error()
+	}
+	return strings.Join(lines, "\n")
+}
+
+func mustParseDuration(s string) time.Duration {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+`
+
+func main() {
+	if len(os.Args) != 2 {
+		log.Fatalf("usage: %s <package-dir>", os.Args[0])
+	}
+	dir := os.Args[1]
+	name := filepath.Base(dir)
+
+	examples, err := findExamples(dir)
+	if err != nil {
+		log.Fatalf("failed to find examples for %s: %s", name, err)
+	}
+
+	config, err := readConfig(filepath.Join(dir, "testdata", "config.yaml"))
+	if err != nil {
+		log.Fatalf("failed to read config for %s: %s", name, err)
+	}
+
+	data := struct {
+		Name      string
+		MaxTokens int
+		Timeout   string
+		Examples  []string
+	}{
+		Name:      name,
+		MaxTokens: config.MaxTokens,
+		Timeout:   config.Timeout,
+		Examples:  examples,
+	}
+
+	testTemplate := template.Must(template.New("test").Parse(TEST))
+
+	out, err := os.Create(filepath.Join(dir, name+"_test.go"))
+	if err != nil {
+		log.Fatalf("failed to create %s_test.go: %s", name, err)
+	}
+	defer out.Close()
+
+	if err := testTemplate.Execute(out, data); err != nil {
+		log.Fatalf("failed to generate %s_test.go: %s", name, err)
+	}
+}
+
+// findExamples returns the grammars-v4/<name>/examples/*.txt files for the
+// grammar package at dir, relative to the repository root (the generated
+// test opens them from its parent directory), sorted for a stable
+// generated test order.
+func findExamples(dir string) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join("grammars-v4", filepath.Base(dir), "examples", "*.txt"))
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(matches)
+	return matches, nil
+}
+
+// readConfig reads testdata/config.yaml if present, and applies defaults
+// for any field it doesn't set.
+func readConfig(path string) (testConfig, error) {
+	config := testConfig{MaxTokens: defaultMaxTokens, Timeout: defaultTimeout}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return config, nil
+	}
+	if err != nil {
+		return config, err
+	}
+
+	if err := parseYAMLConfig(data, &config); err != nil {
+		return config, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	if config.MaxTokens == 0 {
+		config.MaxTokens = defaultMaxTokens
+	}
+	if config.Timeout == "" {
+		config.Timeout = defaultTimeout
+	}
+
+	return config, nil
+}
+
+// parseYAMLConfig parses the handful of "key: value" lines config.yaml
+// actually needs, avoiding a dependency on a full YAML library for two
+// scalar fields.
+func parseYAMLConfig(data []byte, config *testConfig) error {
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("invalid line %q", line)
+		}
+
+		key := strings.TrimSpace(parts[0])
+		value := strings.TrimSpace(parts[1])
+
+		switch key {
+		case "max_tokens":
+			if _, err := fmt.Sscanf(value, "%d", &config.MaxTokens); err != nil {
+				return fmt.Errorf("invalid max_tokens %q: %w", value, err)
+			}
+		case "timeout":
+			config.Timeout = value
+		default:
+			return fmt.Errorf("unknown key %q", key)
+		}
+	}
+	return nil
+}
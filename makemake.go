@@ -12,16 +12,23 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-// makemake extracts the build and test info from the pom.xml to generate
-// a Makefile capable to build and test all the grammars with Go.
+// makemake walks grammars-v4 and, for each grammar, emits a register.go
+// that registers the generated package with bramp.net/antlr4-grammars/grammars.
+// It used to also generate the Makefile; that job now belongs to
+// cmd/antlr4-grammars-build, which discovers grammars the same way but
+// drives ANTLR, go build and go test itself instead of shelling out to make.
+//
+// By default it regenerates every grammar's register.go. Pass -only to
+// regenerate a single package, which is what cmd/antlr4-grammars-build
+// does as part of its per-grammar build step.
 package main
 
 import (
 	"bramp.net/antlr4-grammars/internal"
+	"flag"
 	"log"
 	"os"
 	"path/filepath"
-	"sort"
 	"strings"
 	"text/template"
 )
@@ -34,115 +41,69 @@ var IGNORE = []string{
 	"swift-fin", // The g4 files are nested under a src/main/... directory, which we can't handle.
 }
 
-// MAKEFILE is the template used to build the Makefile.
-// It expects to be executed with a templateData
-const MAKEFILE = `# Copyright 2017 Google Inc.
-#
-# Licensed under the Apache License, Version 2.0 (the "License");
-# you may not use this file except in compliance with the License.
-# You may obtain a copy of the License at
-#
-#     https://www.apache.org/licenses/LICENSE-2.0
-#
-# Unless required by applicable law or agreed to in writing, software
-# distributed under the License is distributed on an "AS IS" BASIS,
-# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
-# See the License for the specific language governing permissions and
-# limitations under the License.
-
-#
-# Do not edit this file, it is generated by makemake.go
-#
-MAKEFLAGS += --no-builtin-rules
-
-.PHONY: all antlr clean rebuild test
-.SILENT:
-.DELETE_ON_ERROR:
-.SUFFIXES:
-
-ANTLR_BIN := $(PWD)/.bin/antlr-4.7-complete.jar
-ANTLR_URL := http://www.antlr.org/download/antlr-4.7-complete.jar
-ANTLR_ARGS := -Dlanguage=Go -visitor
-
-GRAMMARS := {{ Join .Grammars " " }}
-
-LANG_COLOR = \033[0;36m
-NO_COLOR   = \033[m
-
-# This is the default target
-rebuild: antlr test
-
-all:
-	go run makemake.go
-	make clean
-	make -k -j2 rebuild 2> /dev/null
-
-clean:
-	@rm -r $(GRAMMARS) 2> /dev/null || true
-
-antlr: $(ANTLR_BIN)
-$(ANTLR_BIN):
-	mkdir -p .bin
-	curl -o $@ $(ANTLR_URL)
-
-test: {{ range $name, $project := .Projects -}}{{ $name }}/{{ $project.FilePrefix }}_test.go {{ end }}
-
-{{ range $name, $project := .Projects -}}
-{{ $genfiles := (Join (index $.GeneratedFiles $name) " ") }}
-{{ $testfile := (Concat $name "/" $project.FilePrefix "_test.go") }}
-{{ $name }}: {{ $testfile }}
-{{ $genfiles }}: {{ Join $project.Includes " " }}
-{{ $testfile }}: {{ $genfiles }}
-{{- end }}
-
-%_lexer.go %_parser.go:
-	lang=$$(dirname $@); \
-	errors=$$lang/$$(basename $*).errors; \
-	mkdir -p $$lang; \
-	pushd $$(dirname $<) > /dev/null; \
-	java -jar $(ANTLR_BIN) $(ANTLR_ARGS) -package $$lang $(notdir $^) -o ../../$$lang > ../../$$errors 2>&1; \
-	RET=$$?; \
-	popd > /dev/null; \
-	if [ $$RET -ne 0 ]; then \
-		printf "| %s  | $(LANG_COLOR)%-15s$(NO_COLOR) | %-75s |\n" "❌" "$$lang" "antlr: $$(tail -n 1 $$errors)"; \
-		rm $$lang/*.go > /dev/null 2>&1 || true; \
-		exit $$RET; \
-	fi; \
-	shopt -s nullglob; \
-	go build $*_*.go $*parser_*.go >> $$errors 2>&1; \
-	RET=$$?; \
-	if [ $$RET -ne 0 ]; then \
-		printf "| %s  | $(LANG_COLOR)%-15s$(NO_COLOR) | %-75s |\n" "❌" "$$lang" "build: $$(tail -n 1 $$errors)"; \
-		exit $$RET; \
-	fi;
-
-%_test.go:
-	lang=$$(dirname $@); \
-	errors=$$lang/$$(basename $*).errors; \
-	go run maketest.go $$lang >> $$errors 2>&1; \
-	RET=$$?; \
-	if [ $$RET -ne 0 ]; then \
-		printf "| %s  | $(LANG_COLOR)%-15s$(NO_COLOR) | %-75s |\n" "❌" "$$lang" "maketest: $$(tail -n 1 $$errors)"; \
-		exit $$RET; \
-	fi; \
-	go test -timeout 10s ./$$lang >> $$errors 2>&1; \
-	RET=$$?; \
-	if [ $$RET -ne 0 ]; then \
-		printf "| %s  | $(LANG_COLOR)%-15s$(NO_COLOR) | %-75s |\n" "❌" "$$lang" " test: $$(tail -n 1 $$errors)"; \
-		exit $$RET; \
-	fi; \
-	if [[ -s $$errors ]]; then \
-		rm $$errors; \
-		printf "| %s  | $(LANG_COLOR)%-15s$(NO_COLOR) | %-75s |\n" "✅" "$$lang" ""; \
-	else \
-		printf "| %s  | $(LANG_COLOR)%-15s$(NO_COLOR) | %-75s |\n" "⚠️" "$$lang" "$$(tail -n 1 $$errors)"; \
-	fi;
+// REGISTER is the template for the register.go file generated next to
+// each grammar package, so that importing the package (a blank import is
+// enough) makes it available through the grammars registry.
+const REGISTER = `// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//
+// Do not edit this file, it is generated by makemake.go
+//
+package {{ .Name }}
+
+import (
+	"bramp.net/antlr4-grammars/grammars"
+	"github.com/antlr/antlr4/runtime/Go/antlr"
+)
+
+func init() {
+	grammars.Register(&grammar{})
+}
+
+// grammar implements grammars.Grammar for the {{ .Name }} package.
+type grammar struct{}
+
+func (grammar) Name() string { return "{{ .Name }}" }
+
+func (grammar) NewLexer(input antlr.CharStream) antlr.Lexer {
+	return New{{ .Name }}Lexer(input)
+}
+
+func (grammar) NewParser(stream antlr.TokenStream) antlr.Parser {
+	return New{{ .Name }}Parser(stream)
+}
+
+func (grammar) EntryPoint(p antlr.Parser) antlr.ParseTree {
+	return p.(*{{ .Name }}Parser).{{ .EntryPoint }}()
+}
+
+func (grammar) Extensions() []string {
+	return {{ printf "%#v" .Extensions }}
+}
+
+func (grammar) RuleNames() []string {
+	return New{{ .Name }}Parser(nil).GetRuleNames()
+}
 `
 
-type templateData struct {
-	Grammars       []string
-	Projects       map[string]*internal.Project
-	GeneratedFiles map[string][]string
+// registerData is the data passed to the REGISTER template for a single
+// grammar package.
+type registerData struct {
+	Name       string
+	EntryPoint string
+	Extensions []string
 }
 
 // onIgnoreList returns true if the pom file in the path is on the banned list.
@@ -156,6 +117,9 @@ func onIgnoreList(path string) bool {
 }
 
 func main() {
+	only := flag.String("only", "", "if set, regenerate register.go for just this grammar package instead of all of them")
+	flag.Parse()
+
 	projects := make(map[string]*internal.Project)
 
 	err := filepath.Walk(GRAMMARS_ROOT, func(path string, info os.FileInfo, err error) error {
@@ -197,52 +161,34 @@ func main() {
 		log.Fatalf("failed to walk: %s", err)
 	}
 
-	var grammars []string
-	for name := range projects {
-		grammars = append(grammars, name)
+	if *only != "" {
+		project, ok := projects[*only]
+		if !ok {
+			log.Fatalf("unknown grammar package %q", *only)
+		}
+		projects = map[string]*internal.Project{*only: project}
 	}
-	sort.Strings(grammars)
 
-	generatedFiles := make(map[string][]string)
+	registerTemplate := template.Must(template.New("register").Parse(REGISTER))
 	for name, project := range projects {
-		var generated []string
-		for _, file := range project.GeneratedFilenames() {
-			// Full path to generated file
-			generated = append(generated, name+"/"+file)
-		}
-		generatedFiles[name] = generated
-		if len(generated) < 2 {
-			// TODO(bramp): Actually check we have one lexer, and one parser.
-			log.Fatalf("Expect atleast two generated files, only got: %q for %q", generated, name)
+		if err := writeRegisterFile(registerTemplate, name, project); err != nil {
+			log.Fatalf("failed to write %s/register.go: %s", name, err)
 		}
 	}
+}
 
-	data := templateData{
-		Grammars:       grammars,
-		Projects:       projects,
-		GeneratedFiles: generatedFiles,
-	}
-
-	funcs := template.FuncMap{
-		"Join": strings.Join,
-		"Concat": func(strings ...string) string {
-			results := ""
-			for _, s := range strings {
-				results = results + s
-			}
-			return results
-		},
-	}
-
-	makeTemplate := template.Must(template.New("makefile").Funcs(funcs).Parse(MAKEFILE))
-
-	out, err := os.Create("Makefile")
+// writeRegisterFile emits name/register.go, which registers the
+// generated grammar with the top-level grammars package.
+func writeRegisterFile(t *template.Template, name string, project *internal.Project) error {
+	out, err := os.Create(filepath.Join(name, "register.go"))
 	if err != nil {
-		log.Fatalf("failed to create Makefile: %s", err)
-	}
-
-	if err := makeTemplate.Execute(out, data); err != nil {
-		log.Fatalf("failed to generate Makefile: %s", err)
+		return err
 	}
+	defer out.Close()
 
+	return t.Execute(out, registerData{
+		Name:       name,
+		EntryPoint: project.EntryPoint(),
+		Extensions: project.Extensions(),
+	})
 }
@@ -0,0 +1,196 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package parseopt wraps bramp.net/antlr4-grammars/grammars with a small
+// functional-options API, so callers don't have to hand-wire
+// BuildParseTrees, error listeners and prediction modes themselves:
+//
+//	var errs []error
+//	tree, err := parseopt.Parse(g, input,
+//		parseopt.WithErrorCollector(&errs),
+//		parseopt.WithTimeout(10*time.Second),
+//		parseopt.WithMaxTokens(1_000_000))
+package parseopt
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"bramp.net/antlr4-grammars/grammars"
+	"github.com/antlr/antlr4/runtime/Go/antlr"
+)
+
+// defaultMaxTokens mirrors the MAX_TOKENS bound the generated tests used
+// to hardcode, so a grammar with a broken lexer can't hang forever.
+const defaultMaxTokens = 1000000
+
+// options holds the resolved configuration built up by a chain of Option
+// values. It is unexported: callers only ever see Option and Parse.
+type options struct {
+	errs           *[]error
+	timeout        time.Duration
+	maxTokens      int
+	trace          io.Writer
+	predictionMode int
+}
+
+// Option configures a single call to Parse.
+type Option func(*options)
+
+// WithErrorCollector appends every syntax error encountered while
+// lexing or parsing to errs.
+func WithErrorCollector(errs *[]error) Option {
+	return func(o *options) { o.errs = errs }
+}
+
+// WithTimeout bounds the wall-clock time Parse is allowed to take. If d
+// elapses before parsing finishes, Parse returns an error; the parse
+// continues in the background and is abandoned.
+func WithTimeout(d time.Duration) Option {
+	return func(o *options) { o.timeout = d }
+}
+
+// WithMaxTokens bounds how many tokens the lexer will read before Parse
+// gives up and returns an error, protecting against a grammar whose
+// lexer never reaches EOF.
+func WithMaxTokens(n int) Option {
+	return func(o *options) { o.maxTokens = n }
+}
+
+// WithTrace writes a line to w for every parser rule entered, without
+// requiring the caller to reach into the underlying antlr types.
+func WithTrace(w io.Writer) Option {
+	return func(o *options) { o.trace = w }
+}
+
+// WithPredictionMode sets the parser's ALL(*) prediction mode, e.g.
+// antlr.PredictionModeSLL to trade correctness on ambiguous grammars for
+// speed.
+func WithPredictionMode(mode int) Option {
+	return func(o *options) { o.predictionMode = mode }
+}
+
+// Parse lexes and parses input with g, applying opts. It returns the
+// parse tree, or an error if the timeout or max-tokens bound was hit; any
+// syntax errors encountered are reported through WithErrorCollector, not
+// as the returned error.
+func Parse(g grammars.Grammar, input antlr.CharStream, opts ...Option) (antlr.ParseTree, error) {
+	o := &options{maxTokens: defaultMaxTokens, predictionMode: antlr.PredictionModeLL}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	type result struct {
+		tree antlr.ParseTree
+		errs []error
+		err  error
+	}
+
+	done := make(chan result, 1)
+	go func() {
+		tree, errs, err := parse(g, input, o)
+		done <- result{tree, errs, err}
+	}()
+
+	var timeoutCh <-chan time.Time
+	if o.timeout > 0 {
+		timeoutCh = time.After(o.timeout)
+	}
+
+	select {
+	case r := <-done:
+		if o.errs != nil {
+			*o.errs = append(*o.errs, r.errs...)
+		}
+		return r.tree, r.err
+	case <-timeoutCh:
+		return nil, fmt.Errorf("parseopt: parse did not finish within %s", o.timeout)
+	}
+}
+
+// parse runs the real lex+parse pass. Its last return value is a fatal
+// error -- one that means tree is nil and not worth a caller's attention
+// as a syntax error -- distinct from the syntax errors collected in the
+// middle return value.
+func parse(g grammars.Grammar, input antlr.CharStream, o *options) (antlr.ParseTree, []error, error) {
+	if err := checkMaxTokens(g, input, o.maxTokens); err != nil {
+		return nil, nil, err
+	}
+	// checkMaxTokens ran the lexer to completion (or maxTokens, whichever
+	// came first); rewind before the real lex+parse pass.
+	input.Seek(0)
+
+	lexer := g.NewLexer(input)
+
+	errs := &errorCollector{}
+	lexer.RemoveErrorListeners()
+	lexer.AddErrorListener(errs)
+
+	tokens := antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel)
+
+	parser := g.NewParser(tokens)
+	parser.RemoveErrorListeners()
+	parser.AddErrorListener(errs)
+	parser.SetBuildParseTrees(true)
+
+	if i, ok := parser.GetInterpreter().(*antlr.ParserATNSimulator); ok {
+		i.SetPredictionMode(o.predictionMode)
+	}
+
+	if o.trace != nil {
+		parser.AddParseListener(&traceListener{w: o.trace})
+	}
+
+	tree := g.EntryPoint(parser)
+	return tree, errs.errors, nil
+}
+
+// checkMaxTokens reads input's tokens with a throwaway lexer, failing if
+// max is reached before EOF -- a sign the grammar's lexer is stuck.
+func checkMaxTokens(g grammars.Grammar, input antlr.CharStream, max int) error {
+	lexer := g.NewLexer(input)
+
+	for i := 0; i < max; i++ {
+		if lexer.NextToken().GetTokenType() == antlr.TokenEOF {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("parseopt: lexer read %d tokens without finding EOF", max)
+}
+
+// errorCollector is an antlr.ErrorListener that records every syntax
+// error instead of printing it to stderr.
+type errorCollector struct {
+	*antlr.DefaultErrorListener
+	errors []error
+}
+
+func (e *errorCollector) SyntaxError(recognizer antlr.Recognizer, offendingSymbol interface{}, line, column int, msg string, ex antlr.RecognitionException) {
+	e.errors = append(e.errors, fmt.Errorf("line %d:%d %s", line, column, msg))
+}
+
+// traceListener writes one line per rule entered, for WithTrace.
+type traceListener struct {
+	w io.Writer
+}
+
+func (t *traceListener) VisitTerminal(node antlr.TerminalNode) {}
+func (t *traceListener) VisitErrorNode(node antlr.ErrorNode)   {}
+func (t *traceListener) ExitEveryRule(ctx antlr.ParserRuleContext) {}
+
+func (t *traceListener) EnterEveryRule(ctx antlr.ParserRuleContext) {
+	fmt.Fprintf(t.w, "enter %s\n", ctx.GetText())
+}
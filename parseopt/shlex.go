@@ -0,0 +1,147 @@
+// Copyright 2017 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parseopt
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/antlr/antlr4/runtime/Go/antlr"
+)
+
+// Shlex parses a shell-style option spec into the equivalent []Option, so
+// a command-line tool built on top of a grammar package can accept a
+// single config string instead of wiring up its own flag set:
+//
+//	opts, err := parseopt.Shlex("--sll --max-tokens=1M --trace")
+//	tree, err := parseopt.Parse(g, input, opts...)
+//
+// Recognised flags are --sll, --ll (the default, included for
+// completeness), --max-tokens=N (N may end in K or M), --timeout=DURATION
+// (parsed by time.ParseDuration) and --trace (writes to os.Stderr).
+func Shlex(cmd string) ([]Option, error) {
+	fields, err := shlexSplit(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	var opts []Option
+	for _, field := range fields {
+		opt, err := parseFlag(field)
+		if err != nil {
+			return nil, fmt.Errorf("parseopt.Shlex(%q): %w", cmd, err)
+		}
+		opts = append(opts, opt)
+	}
+
+	return opts, nil
+}
+
+func parseFlag(field string) (Option, error) {
+	name, value := field, ""
+	hasValue := false
+	if i := strings.Index(field, "="); i >= 0 {
+		name, value, hasValue = field[:i], field[i+1:], true
+	}
+
+	switch name {
+	case "--sll":
+		return WithPredictionMode(antlr.PredictionModeSLL), nil
+	case "--ll":
+		return WithPredictionMode(antlr.PredictionModeLL), nil
+	case "--trace":
+		return WithTrace(os.Stderr), nil
+	case "--max-tokens":
+		if !hasValue {
+			return nil, fmt.Errorf("%s requires a value, e.g. %s=1M", name, name)
+		}
+		n, err := parseCount(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		return WithMaxTokens(n), nil
+	case "--timeout":
+		if !hasValue {
+			return nil, fmt.Errorf("%s requires a value, e.g. %s=10s", name, name)
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		return WithTimeout(d), nil
+	default:
+		return nil, fmt.Errorf("unknown flag %q", field)
+	}
+}
+
+// parseCount parses a token count like "1000000" or "1M" or "512K".
+func parseCount(s string) (int, error) {
+	mult := 1
+	switch {
+	case strings.HasSuffix(s, "K"):
+		mult, s = 1000, strings.TrimSuffix(s, "K")
+	case strings.HasSuffix(s, "M"):
+		mult, s = 1000000, strings.TrimSuffix(s, "M")
+	}
+
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid count %q", s)
+	}
+	return n * mult, nil
+}
+
+// shlexSplit splits cmd the way a POSIX shell would tokenize a single
+// line: whitespace-separated words, with '...' and "..." quoting.
+func shlexSplit(cmd string) ([]string, error) {
+	var fields []string
+	var field strings.Builder
+	inField := false
+
+	runes := []rune(cmd)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '\'' || r == '"':
+			inField = true
+			quote := r
+			i++
+			for ; i < len(runes) && runes[i] != quote; i++ {
+				field.WriteRune(runes[i])
+			}
+			if i == len(runes) {
+				return nil, fmt.Errorf("unterminated %c quote", quote)
+			}
+		case r == ' ' || r == '\t':
+			if inField {
+				fields = append(fields, field.String())
+				field.Reset()
+				inField = false
+			}
+		default:
+			inField = true
+			field.WriteRune(r)
+		}
+	}
+
+	if inField {
+		fields = append(fields, field.String())
+	}
+
+	return fields, nil
+}